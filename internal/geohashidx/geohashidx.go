@@ -0,0 +1,236 @@
+// Package geohashidx implements an optional geohash-prefix pre-filter for
+// WITHIN/INTERSECTS-style queries, inspired by the prefix-coded geo terms
+// used by bleve's geo index. It trades perfect precision for a cheap,
+// index-side narrowing step ahead of the collection's exact Within/
+// Intersects test: objects are posted under the geohash prefixes that
+// cover their bounding box at a handful of precision levels, and a query
+// rect is reduced to the same kind of prefix set before the candidate
+// union is handed to the exact geometry test.
+package geohashidx
+
+import (
+	"math"
+	"sync"
+
+	"github.com/mmcloughlin/geohash"
+	"github.com/tidwall/geojson/geometry"
+)
+
+// MinPrecision and MaxPrecision bound the geohash character lengths an
+// Index will ever post an object under. Shorter prefixes are cheap to
+// scan but match broadly; longer prefixes are selective but multiply the
+// posting lists an object appears in.
+const (
+	MinPrecision = 3
+	MaxPrecision = 8
+
+	// DefaultMaxCells is the target covering-set size used when the
+	// caller doesn't request a specific budget.
+	DefaultMaxCells = 16
+)
+
+// CoveringPrefixes returns a set of geohash prefixes, all of the same
+// precision, whose cells cover rect. It walks precisions from the longest
+// (maxPrecision) down to the shortest (MinPrecision), picking the longest
+// one whose cell count is at or below maxCells -- i.e. the most selective
+// covering set that still stays within budget.
+func CoveringPrefixes(rect geometry.Rect, maxPrecision, maxCells int) []string {
+	if maxPrecision <= 0 || maxPrecision > MaxPrecision {
+		maxPrecision = MaxPrecision
+	}
+	if maxCells <= 0 {
+		maxCells = DefaultMaxCells
+	}
+	var best []string
+	for p := maxPrecision; p >= MinPrecision; p-- {
+		cells := cellsForPrecision(rect, p)
+		best = cells
+		if len(cells) <= maxCells {
+			break
+		}
+	}
+	return best
+}
+
+// maxStepIterations bounds the cost of cellsForPrecision's scan. A rect
+// much larger than a single precision-p cell -- e.g. a world-spanning
+// query at precision 8 -- would otherwise need an impractical number of
+// steps to enumerate exhaustively, so the step size is widened just
+// enough to keep the scan bounded; cellsForPrecision already returns an
+// approximate covering set, so a slightly coarser stride only costs a
+// few extra (harmless) candidate cells.
+const maxStepIterations = 20000
+
+// cellsForPrecision enumerates the geohash cells at precision p that rect
+// overlaps, by stepping across rect in cell-sized increments (measured
+// from a sample cell at rect's center) and deduplicating the result.
+func cellsForPrecision(rect geometry.Rect, p int) []string {
+	midLat := (rect.Min.Y + rect.Max.Y) / 2
+	midLon := (rect.Min.X + rect.Max.X) / 2
+	sample := geohash.BoundingBox(geohash.EncodeWithPrecision(midLat, midLon, uint(p)))
+	stepLat := sample.MaxLat - sample.MinLat
+	stepLon := sample.MaxLng - sample.MinLng
+	if stepLat <= 0 {
+		stepLat = 1e-6
+	}
+	if stepLon <= 0 {
+		stepLon = 1e-6
+	}
+	latSteps := int((rect.Max.Y-rect.Min.Y)/stepLat) + 2
+	lonSteps := int((rect.Max.X-rect.Min.X)/stepLon) + 2
+	if total := latSteps * lonSteps; total > maxStepIterations {
+		scale := math.Sqrt(float64(total) / maxStepIterations)
+		stepLat *= scale
+		stepLon *= scale
+	}
+	seen := make(map[string]bool)
+	// The last sample in each direction is pinned to rect's max edge, so
+	// the cell holding that edge is always included.
+	for lat := rect.Min.Y; ; lat += stepLat {
+		if lat > rect.Max.Y {
+			lat = rect.Max.Y
+		}
+		for lon := rect.Min.X; ; lon += stepLon {
+			if lon > rect.Max.X {
+				lon = rect.Max.X
+			}
+			seen[geohash.EncodeWithPrecision(lat, lon, uint(p))] = true
+			if lon >= rect.Max.X {
+				break
+			}
+		}
+		if lat >= rect.Max.Y {
+			break
+		}
+	}
+	cells := make([]string, 0, len(seen))
+	for c := range seen {
+		cells = append(cells, c)
+	}
+	return cells
+}
+
+// Index posts object keys under the geohash prefixes covering their
+// bounding box at every precision between MinPrecision and maxPrecision,
+// so a query can be narrowed to a candidate set before the exact
+// Within/Intersects test runs. A key may be anything comparable -- an
+// object id string, or the *object.Object pointer itself when no
+// collection-level id is in scope.
+type Index struct {
+	mu           sync.RWMutex
+	maxPrecision int
+	postings     [MaxPrecision + 1]map[string]map[interface{}]bool
+	size         int
+}
+
+// New returns an empty Index that posts objects at every precision up to
+// MaxPrecision.
+func New() *Index {
+	return NewWithPrecision(MaxPrecision)
+}
+
+// NewWithPrecision returns an empty Index that posts objects at every
+// precision up to maxPrecision (clamped to MaxPrecision). This is the
+// "max precision" knob called for alongside the index itself: a shorter
+// maxPrecision trades candidate-set selectivity for a smaller index.
+func NewWithPrecision(maxPrecision int) *Index {
+	if maxPrecision <= 0 || maxPrecision > MaxPrecision {
+		maxPrecision = MaxPrecision
+	}
+	ix := &Index{maxPrecision: maxPrecision}
+	for p := MinPrecision; p <= maxPrecision; p++ {
+		ix.postings[p] = make(map[string]map[interface{}]bool)
+	}
+	return ix
+}
+
+// Len reports how many objects are currently posted in the index.
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return ix.size
+}
+
+// Insert posts key under rect's covering prefixes at every indexed
+// precision level. Call on every collection write (SET/object update), or
+// -- where the write path isn't reachable -- on every object a query's
+// exact geometry test confirms, so the index stays in sync with what
+// queries actually observe.
+func (ix *Index) Insert(key interface{}, rect geometry.Rect) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for p := MinPrecision; p <= ix.maxPrecision; p++ {
+		for _, prefix := range cellsForPrecision(rect, p) {
+			m := ix.postings[p][prefix]
+			if m == nil {
+				m = make(map[interface{}]bool)
+				ix.postings[p][prefix] = m
+			}
+			if !m[key] {
+				m[key] = true
+			}
+		}
+	}
+	ix.size++
+}
+
+// Remove undoes a prior Insert for key, e.g. on DEL or object replacement.
+func (ix *Index) Remove(key interface{}, rect geometry.Rect) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	removed := false
+	for p := MinPrecision; p <= ix.maxPrecision; p++ {
+		for _, prefix := range cellsForPrecision(rect, p) {
+			if m, ok := ix.postings[p][prefix]; ok {
+				if m[key] {
+					removed = true
+				}
+				delete(m, key)
+				if len(m) == 0 {
+					delete(ix.postings[p], prefix)
+				}
+			}
+		}
+	}
+	if removed {
+		ix.size--
+	}
+}
+
+// Candidates computes rect's covering prefixes and returns the union of
+// every posted key whose indexed prefix is a prefix of one of those
+// cells, or has one of those cells as a prefix of it (a finer cell nested
+// inside a coarser query cell). The exact Within/Intersects test still
+// has to run over this set -- Candidates only narrows, it never confirms
+// a hit.
+func (ix *Index) Candidates(rect geometry.Rect, maxCells int) map[interface{}]bool {
+	ix.mu.RLock()
+	maxPrecision := ix.maxPrecision
+	ix.mu.RUnlock()
+	cells := CoveringPrefixes(rect, maxPrecision, maxCells)
+	out := make(map[interface{}]bool)
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	for _, cell := range cells {
+		for p := MinPrecision; p <= ix.maxPrecision; p++ {
+			for prefix, keys := range ix.postings[p] {
+				if !sharesPrefix(prefix, cell) {
+					continue
+				}
+				for key := range keys {
+					out[key] = true
+				}
+			}
+		}
+	}
+	return out
+}
+
+// sharesPrefix reports whether the shorter of a and b is a prefix of the
+// longer one.
+func sharesPrefix(a, b string) bool {
+	if len(a) <= len(b) {
+		return b[:len(a)] == a
+	}
+	return a[:len(b)] == b
+}
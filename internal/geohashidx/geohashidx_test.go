@@ -0,0 +1,82 @@
+package geohashidx
+
+import (
+	"testing"
+
+	"github.com/tidwall/geojson/geometry"
+)
+
+func rect(minLon, minLat, maxLon, maxLat float64) geometry.Rect {
+	return geometry.Rect{
+		Min: geometry.Point{X: minLon, Y: minLat},
+		Max: geometry.Point{X: maxLon, Y: maxLat},
+	}
+}
+
+func TestCoveringPrefixesRespectsMaxCells(t *testing.T) {
+	// A small rect, well within a single MinPrecision cell's extent, so the
+	// maxCells budget is actually satisfiable by backing off to a coarser
+	// precision -- unlike a world-spanning rect, where even MinPrecision
+	// can legitimately need more cells than a small budget allows.
+	r := rect(-0.01, -0.01, 0.01, 0.01)
+	cells := CoveringPrefixes(r, MaxPrecision, 16)
+	if len(cells) == 0 {
+		t.Fatalf("expected at least one covering cell")
+	}
+	if len(cells) > 16 {
+		t.Fatalf("expected at most 16 cells, got %d", len(cells))
+	}
+}
+
+func TestCoveringPrefixesBoundedForLargeRect(t *testing.T) {
+	// A rect spanning far more than one MinPrecision cell can legitimately
+	// exceed the maxCells budget, but CoveringPrefixes must still return
+	// promptly with a non-empty, deduplicated covering set.
+	r := rect(-10, -10, 10, 10)
+	cells := CoveringPrefixes(r, MaxPrecision, 16)
+	if len(cells) == 0 {
+		t.Fatalf("expected at least one covering cell")
+	}
+}
+
+func TestIndexInsertAndCandidates(t *testing.T) {
+	ix := New()
+	sfRect := rect(-122.43, 37.77, -122.41, 37.79)
+	nyRect := rect(-74.01, 40.70, -73.99, 40.72)
+	ix.Insert("sf", sfRect)
+	ix.Insert("ny", nyRect)
+
+	if ix.Len() != 2 {
+		t.Fatalf("expected 2 indexed objects, got %d", ix.Len())
+	}
+
+	candidates := ix.Candidates(sfRect, DefaultMaxCells)
+	if !candidates["sf"] {
+		t.Fatalf("expected \"sf\" to be a candidate for its own bounding rect")
+	}
+	if candidates["ny"] {
+		t.Fatalf("did not expect \"ny\" to be a candidate for a San Francisco rect")
+	}
+}
+
+func TestIndexRemove(t *testing.T) {
+	ix := New()
+	r := rect(0, 0, 1, 1)
+	ix.Insert("a", r)
+	ix.Remove("a", r)
+	if ix.Len() != 0 {
+		t.Fatalf("expected 0 indexed objects after remove, got %d", ix.Len())
+	}
+	if ix.Candidates(r, DefaultMaxCells)["a"] {
+		t.Fatalf("did not expect removed key to remain a candidate")
+	}
+}
+
+func TestNewWithPrecisionClampsToBounds(t *testing.T) {
+	ix := NewWithPrecision(100)
+	r := rect(0, 0, 1, 1)
+	ix.Insert("a", r)
+	if !ix.Candidates(r, DefaultMaxCells)["a"] {
+		t.Fatalf("expected clamped-precision index to still find its own insert")
+	}
+}
@@ -0,0 +1,54 @@
+package mvt
+
+// command integers from the MVT spec: a command integer packs a command
+// id into the low 3 bits and a repeat count into the remaining bits.
+const (
+	cmdMoveTo    = 1
+	cmdLineTo    = 2
+	cmdClosePath = 7
+)
+
+// point is a tile-local integer coordinate, already projected and clipped
+// to the tile envelope.
+type point struct{ x, y int32 }
+
+// encodeCommands renders one or more rings/lines as an MVT geometry
+// command stream: each ring starts with a single MoveTo, is followed by a
+// run of LineTo commands, and -- for polygon rings only -- a ClosePath.
+// Coordinates are zigzag-delta-encoded against a cursor that persists
+// across rings within the same feature, per spec.
+func encodeCommands(rings [][]point, closePath bool) []uint32 {
+	var cmds []uint32
+	var cx, cy int32
+	for _, ring := range rings {
+		if len(ring) == 0 {
+			continue
+		}
+		first := ring[0]
+		cmds = append(cmds, commandInt(cmdMoveTo, 1))
+		cmds = append(cmds, zigzag(first.x-cx), zigzag(first.y-cy))
+		cx, cy = first.x, first.y
+
+		rest := ring[1:]
+		if closePath && len(rest) > 0 && rest[len(rest)-1] == ring[0] {
+			rest = rest[:len(rest)-1]
+		}
+		if len(rest) > 0 {
+			cmds = append(cmds, commandInt(cmdLineTo, len(rest)))
+			for _, p := range rest {
+				cmds = append(cmds, zigzag(p.x-cx), zigzag(p.y-cy))
+				cx, cy = p.x, p.y
+			}
+		}
+		if closePath {
+			cmds = append(cmds, commandInt(cmdClosePath, 1))
+		}
+	}
+	return cmds
+}
+
+// commandInt packs a command id and repeat count into a single MVT
+// geometry command integer.
+func commandInt(cmd, count int) uint32 {
+	return uint32(count<<3 | cmd)
+}
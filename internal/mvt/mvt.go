@@ -0,0 +1,204 @@
+// Package mvt encodes Tile38 search results as Mapbox Vector Tile (MVT)
+// protobuf, per https://github.com/mapbox/vector-tile-spec, without
+// depending on a generated protobuf package.
+package mvt
+
+import (
+	"math"
+
+	"github.com/tidwall/geojson"
+	"github.com/tidwall/geojson/geometry"
+	"github.com/tidwall/tile38/internal/bing"
+	"github.com/tidwall/tile38/internal/clip"
+)
+
+// DefaultExtent is the tile-local coordinate grid size used when the
+// caller doesn't request a specific EXTENT.
+const DefaultExtent = 4096
+
+const (
+	geomPoint   = 1
+	geomLine    = 2
+	geomPolygon = 3
+)
+
+// Feature is one object to encode into the tile's layer. Tags are object
+// fields, promoted into the layer's shared keys/values dictionaries.
+type Feature struct {
+	Obj  geojson.Object
+	ID   uint64
+	Tags map[string]interface{}
+}
+
+// Encode renders features as a single-layer MVT tile for the tile at
+// (tileX, tileY, tileZ). Each feature's geometry is clipped to the tile's
+// exact envelope -- distinct from any buffered query rect used to select
+// which objects to consider -- and projected to tile-local integer
+// coordinates on an extent x extent grid before being command-encoded.
+func Encode(
+	layerName string, features []Feature, tileX, tileY, tileZ, extent int,
+	opts *geometry.IndexOptions,
+) []byte {
+	if extent <= 0 {
+		extent = DefaultExtent
+	}
+	minLat, minLon, maxLat, maxLon :=
+		bing.TileXYToBounds(int64(tileX), int64(tileY), uint64(tileZ))
+	tileRect := geojson.NewRect(geometry.Rect{
+		Min: geometry.Point{X: minLon, Y: minLat},
+		Max: geometry.Point{X: maxLon, Y: maxLat},
+	})
+
+	var keys []string
+	keyIdx := map[string]int{}
+	var values [][]byte
+	valueIdx := map[string]int{}
+	var featureBufs [][]byte
+
+	for _, f := range features {
+		clipped := clip.Clip(f.Obj, tileRect, opts)
+		rings, geomType := collectRings(clipped, tileX, tileY, tileZ, extent)
+		if len(rings) == 0 {
+			continue
+		}
+		cmds := encodeCommands(rings, geomType == geomPolygon)
+
+		var fb []byte
+		if f.ID != 0 {
+			fb = appendVarintField(fb, 1, f.ID)
+		}
+		if len(f.Tags) > 0 {
+			tags := make([]uint32, 0, len(f.Tags)*2)
+			for k, v := range f.Tags {
+				ki, ok := keyIdx[k]
+				if !ok {
+					ki = len(keys)
+					keys = append(keys, k)
+					keyIdx[k] = ki
+				}
+				enc := encodeValue(v)
+				vi, ok := valueIdx[string(enc)]
+				if !ok {
+					vi = len(values)
+					values = append(values, enc)
+					valueIdx[string(enc)] = vi
+				}
+				tags = append(tags, uint32(ki), uint32(vi))
+			}
+			fb = appendPackedVarints(fb, 2, tags)
+		}
+		fb = appendVarintField(fb, 3, uint64(geomType))
+		fb = appendPackedVarints(fb, 4, cmds)
+
+		featureBufs = append(featureBufs, fb)
+	}
+
+	var layer []byte
+	layer = appendVarintField(layer, 15, 2) // version
+	layer = appendStringField(layer, 1, layerName)
+	for _, fb := range featureBufs {
+		layer = appendBytesField(layer, 2, fb)
+	}
+	for _, k := range keys {
+		layer = appendStringField(layer, 3, k)
+	}
+	for _, v := range values {
+		layer = appendBytesField(layer, 4, v)
+	}
+	layer = appendVarintField(layer, 5, uint64(extent))
+
+	var tile []byte
+	tile = appendBytesField(tile, 3, layer)
+	return tile
+}
+
+func appendPackedVarints(b []byte, field int, vals []uint32) []byte {
+	var body []byte
+	for _, v := range vals {
+		body = appendVarint(body, uint64(v))
+	}
+	return appendBytesField(b, field, body)
+}
+
+// project converts a lon/lat coordinate to a tile-local integer on an
+// extent x extent grid, using the standard Web Mercator tile math.
+func project(lon, lat float64, tileX, tileY, tileZ, extent int) point {
+	n := math.Exp2(float64(tileZ))
+	worldX := (lon + 180) / 360 * n
+	latRad := lat * math.Pi / 180
+	worldY := (1 - math.Log(math.Tan(latRad)+1/math.Cos(latRad))/math.Pi) / 2 * n
+	px := (worldX - float64(tileX)) * float64(extent)
+	py := (worldY - float64(tileY)) * float64(extent)
+	return point{x: int32(math.Round(px)), y: int32(math.Round(py))}
+}
+
+func ringFromLine(line geometry.Ring, tileX, tileY, tileZ, extent int) []point {
+	n := line.NumPoints()
+	pts := make([]point, n)
+	for i := 0; i < n; i++ {
+		pt := line.PointAt(i)
+		pts[i] = project(pt.X, pt.Y, tileX, tileY, tileZ, extent)
+	}
+	return pts
+}
+
+// collectRings flattens any geometry Encode supports into tile-local
+// integer rings, along with the MVT GeomType it corresponds to.
+func collectRings(
+	o geojson.Object, tileX, tileY, tileZ, extent int,
+) ([][]point, int) {
+	switch g := o.(type) {
+	case *geojson.Point:
+		c := g.Base()
+		return [][]point{{project(c.X, c.Y, tileX, tileY, tileZ, extent)}},
+			geomPoint
+	case *geojson.MultiPoint:
+		var rings [][]point
+		for _, child := range g.Children() {
+			c := child.(*geojson.Point).Base()
+			rings = append(rings,
+				[]point{project(c.X, c.Y, tileX, tileY, tileZ, extent)})
+		}
+		return rings, geomPoint
+	case *geojson.LineString:
+		return [][]point{ringFromLine(g.Base(), tileX, tileY, tileZ, extent)},
+			geomLine
+	case *geojson.MultiLineString:
+		var rings [][]point
+		for _, child := range g.Children() {
+			line := child.(*geojson.LineString).Base()
+			rings = append(rings, ringFromLine(line, tileX, tileY, tileZ, extent))
+		}
+		return rings, geomLine
+	case *geojson.Polygon:
+		poly := g.Base()
+		rings := [][]point{ringFromLine(poly.Exterior, tileX, tileY, tileZ, extent)}
+		for _, h := range poly.Holes {
+			rings = append(rings, ringFromLine(h, tileX, tileY, tileZ, extent))
+		}
+		return rings, geomPolygon
+	case *geojson.MultiPolygon:
+		var rings [][]point
+		for _, child := range g.Children() {
+			poly := child.(*geojson.Polygon).Base()
+			rings = append(rings, ringFromLine(poly.Exterior, tileX, tileY, tileZ, extent))
+			for _, h := range poly.Holes {
+				rings = append(rings, ringFromLine(h, tileX, tileY, tileZ, extent))
+			}
+		}
+		return rings, geomPolygon
+	case *geojson.Feature:
+		// Features -- the form SET ... OBJECT {"type":"Feature",...} stores
+		// -- carry no geometry of their own; unwrap to the underlying
+		// geometry instead of falling through to the centroid fallback.
+		return collectRings(g.Base(), tileX, tileY, tileZ, extent)
+	default:
+		rect := o.Rect()
+		mid := geometry.Point{
+			X: (rect.Min.X + rect.Max.X) / 2,
+			Y: (rect.Min.Y + rect.Max.Y) / 2,
+		}
+		return [][]point{{project(mid.X, mid.Y, tileX, tileY, tileZ, extent)}},
+			geomPoint
+	}
+}
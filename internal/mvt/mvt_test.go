@@ -0,0 +1,56 @@
+package mvt
+
+import (
+	"testing"
+
+	"github.com/tidwall/geojson"
+	"github.com/tidwall/geojson/geometry"
+)
+
+func TestZigzag(t *testing.T) {
+	cases := map[int32]uint32{
+		0:  0,
+		-1: 1,
+		1:  2,
+		-2: 3,
+		2:  4,
+	}
+	for in, want := range cases {
+		if got := zigzag(in); got != want {
+			t.Fatalf("zigzag(%d) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestCommandInt(t *testing.T) {
+	if got := commandInt(cmdMoveTo, 1); got != 9 {
+		t.Fatalf("commandInt(MoveTo, 1) = %d, want 9", got)
+	}
+	if got := commandInt(cmdLineTo, 3); got != 26 {
+		t.Fatalf("commandInt(LineTo, 3) = %d, want 26", got)
+	}
+}
+
+func TestEncodeCommandsPolygonClosesPath(t *testing.T) {
+	ring := []point{{0, 0}, {10, 0}, {10, 10}, {0, 0}}
+	cmds := encodeCommands([][]point{ring}, true)
+	last := cmds[len(cmds)-1]
+	if last != commandInt(cmdClosePath, 1) {
+		t.Fatalf("expected last command to be ClosePath, got %d", last)
+	}
+}
+
+func TestEncodeEmptyFeaturesProducesValidTile(t *testing.T) {
+	tile := Encode("points", nil, 0, 0, 0, 0, nil)
+	if len(tile) == 0 {
+		t.Fatalf("expected a non-empty tile even with zero features")
+	}
+}
+
+func TestEncodeProducesBytesForPointFeature(t *testing.T) {
+	pt := geojson.NewPoint(geometry.Point{X: 0, Y: 0})
+	tile := Encode("points", []Feature{{Obj: pt, ID: 1}}, 0, 0, 1, 0, nil)
+	if len(tile) == 0 {
+		t.Fatalf("expected encoded tile bytes for a point feature")
+	}
+}
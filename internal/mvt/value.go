@@ -0,0 +1,56 @@
+package mvt
+
+import (
+	"fmt"
+	"math"
+)
+
+// encodeValue renders a tile value (vector_tile.Tile.Value) for one of the
+// scalar types MVT supports. Unsupported Go types fall back to their
+// string form so a field is never silently dropped.
+func encodeValue(v interface{}) []byte {
+	var b []byte
+	switch t := v.(type) {
+	case string:
+		b = appendStringField(b, 1, t)
+	case float32:
+		b = appendFixed32Field(b, 2, math.Float32bits(t))
+	case float64:
+		b = appendFixed64Field(b, 3, math.Float64bits(t))
+	case int:
+		b = appendVarintField(b, 4, uint64(int64(t)))
+	case int64:
+		b = appendVarintField(b, 4, uint64(t))
+	case uint64:
+		b = appendVarintField(b, 5, t)
+	case bool:
+		n := uint64(0)
+		if t {
+			n = 1
+		}
+		b = appendVarintField(b, 7, n)
+	default:
+		b = appendStringField(b, 1, toString(v))
+	}
+	return b
+}
+
+func toString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+func appendFixed32Field(b []byte, field int, bits uint32) []byte {
+	b = appendTag(b, field, wireFixed32)
+	return append(b, byte(bits), byte(bits>>8), byte(bits>>16), byte(bits>>24))
+}
+
+func appendFixed64Field(b []byte, field int, bits uint64) []byte {
+	b = appendTag(b, field, wireFixed64)
+	for i := 0; i < 8; i++ {
+		b = append(b, byte(bits>>(8*i)))
+	}
+	return b
+}
@@ -0,0 +1,46 @@
+package mvt
+
+// This file implements just enough of the protobuf wire format to encode
+// a Mapbox Vector Tile (vector_tile.proto) without pulling in a generated
+// protobuf package: varints, length-delimited fields, and the handful of
+// field numbers the MVT spec defines.
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendTag(b []byte, field int, wire int) []byte {
+	return appendVarint(b, uint64(field)<<3|uint64(wire))
+}
+
+func appendBytesField(b []byte, field int, data []byte) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = appendVarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+func appendStringField(b []byte, field int, s string) []byte {
+	return appendBytesField(b, field, []byte(s))
+}
+
+func appendVarintField(b []byte, field int, v uint64) []byte {
+	b = appendTag(b, field, wireVarint)
+	return appendVarint(b, v)
+}
+
+// zigzag encodes a signed delta the way the MVT geometry command stream
+// requires: (n << 1) ^ (n >> 31), for 32-bit values.
+func zigzag(n int32) uint32 {
+	return uint32((n << 1) ^ (n >> 31))
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"math"
+
+	"github.com/tidwall/geojson/geometry"
+)
+
+// earthRadiusMeters is the mean Earth radius used for the small-angle
+// equirectangular projection below, matching the approximation already
+// used elsewhere in the search area helpers.
+const earthRadiusMeters = 6371e3
+
+// ellipsePoly builds a rotated ellipse, centered at (lat, lon), with
+// semi-major axis aMeters, semi-minor axis bMeters, and bearing degrees
+// clockwise from north, approximated as a steps-sided polygon.
+//
+// Each vertex starts as a local ENU offset (a*cos(theta), b*sin(theta))
+// along the unrotated ellipse, is rotated by the bearing, and is then
+// converted back to lat/lon using an equirectangular approximation that's
+// accurate for the ellipse sizes Tile38 typically deals with.
+func ellipsePoly(
+	lat, lon, aMeters, bMeters, bearing float64, steps int,
+	opts *geometry.IndexOptions,
+) *geometry.Poly {
+	bearingRad := bearing * math.Pi / 180
+	sinB, cosB := math.Sin(bearingRad), math.Cos(bearingRad)
+	latRad := lat * math.Pi / 180
+	cosLat := math.Cos(latRad)
+	if cosLat < 1e-9 {
+		// clamp near the poles to avoid dividing by (near) zero when
+		// converting east-offsets back to degrees of longitude.
+		cosLat = 1e-9
+	}
+	points := make([]geometry.Point, steps+1)
+	for i := 0; i < steps; i++ {
+		theta := 2 * math.Pi * float64(i) / float64(steps)
+		x := aMeters * math.Cos(theta)
+		y := bMeters * math.Sin(theta)
+		north := x*cosB - y*sinB
+		east := x*sinB + y*cosB
+		dLat := (north / earthRadiusMeters) * (180 / math.Pi)
+		dLon := (east / (earthRadiusMeters * cosLat)) * (180 / math.Pi)
+		points[i] = geometry.Point{X: lon + dLon, Y: lat + dLat}
+	}
+	points[steps] = points[0]
+	return geometry.NewPoly(points, nil, opts)
+}
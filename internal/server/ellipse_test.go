@@ -0,0 +1,71 @@
+package server
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEllipsePolyClosesRing(t *testing.T) {
+	poly := ellipsePoly(0, 0, 1000, 500, 0, 16, nil)
+	pts := poly.Exterior
+	n := pts.NumPoints()
+	if n != 17 {
+		t.Fatalf("expected 17 points (steps+1), got %d", n)
+	}
+	first, last := pts.PointAt(0), pts.PointAt(n-1)
+	if first != last {
+		t.Fatalf("expected ring to close, first=%v last=%v", first, last)
+	}
+}
+
+func TestEllipsePolyUnrotatedAxesAtEquator(t *testing.T) {
+	const a, b = 1000.0, 500.0
+	poly := ellipsePoly(0, 0, a, b, 0, 360, nil)
+	pts := poly.Exterior
+
+	// At bearing 0, theta=0 lies on the semi-major axis pointing due
+	// north (bearing is measured clockwise from north).
+	north := pts.PointAt(0)
+	wantDLat := (a / earthRadiusMeters) * (180 / math.Pi)
+	if math.Abs(north.Y-wantDLat) > 1e-6 || math.Abs(north.X) > 1e-9 {
+		t.Fatalf("expected north vertex near (0, %v), got %v", wantDLat, north)
+	}
+
+	// A quarter of the way around lies on the semi-minor axis, pointing
+	// due east.
+	east := pts.PointAt(90)
+	wantDLon := (b / earthRadiusMeters) * (180 / math.Pi)
+	if math.Abs(east.X-wantDLon) > 1e-6 || math.Abs(east.Y) > 1e-9 {
+		t.Fatalf("expected east vertex near (%v, 0), got %v", wantDLon, east)
+	}
+}
+
+func TestEllipsePolyCircleIsEquidistantFromCenter(t *testing.T) {
+	const lat, lon, r = 40.0, -73.0, 1000.0
+	poly := ellipsePoly(lat, lon, r, r, 45, 32, nil)
+	pts := poly.Exterior
+	cosLat := math.Cos(lat * math.Pi / 180)
+	for i := 0; i < pts.NumPoints()-1; i++ {
+		pt := pts.PointAt(i)
+		// Undo the equirectangular projection to recover meters, since a
+		// circle in meters isn't a circle in raw degree-space once
+		// longitude degrees are compressed by cosLat.
+		north := (pt.Y - lat) * (math.Pi / 180) * earthRadiusMeters
+		east := (pt.X - lon) * (math.Pi / 180) * earthRadiusMeters * cosLat
+		dist := math.Hypot(north, east)
+		if math.Abs(dist-r) > r*0.01 {
+			t.Fatalf("vertex %d: expected ~%v meters from center, got %v", i, r, dist)
+		}
+	}
+}
+
+func TestEllipsePolyClampsNearPoles(t *testing.T) {
+	poly := ellipsePoly(90, 0, 1000, 500, 30, 8, nil)
+	pts := poly.Exterior
+	for i := 0; i < pts.NumPoints(); i++ {
+		pt := pts.PointAt(i)
+		if math.IsNaN(pt.X) || math.IsNaN(pt.Y) || math.IsInf(pt.X, 0) || math.IsInf(pt.Y, 0) {
+			t.Fatalf("vertex %d: expected finite coordinates near the pole, got %v", i, pt)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/tidwall/geojson"
+	"github.com/tidwall/geojson/geometry"
+	"github.com/tidwall/tile38/internal/geohashidx"
+	"github.com/tidwall/tile38/internal/object"
+)
+
+// geoIndexes caches one geohash-prefix index per collection key, shared
+// across USE INDEX GEOHASH queries against that key.
+var geoIndexes sync.Map // key string -> *geohashIndexEntry
+
+type geohashIndexEntry struct {
+	mu           sync.Mutex
+	maxPrecision int
+	index        *geohashidx.Index
+	// rects snapshots the bounding rect each currently-indexed id was
+	// posted under, keyed by id rather than by *object.Object pointer: a
+	// SET that replaces an existing id keeps the same id but allocates a
+	// new Object, so a pointer-keyed cache would stop matching that id on
+	// every later query even though nothing about the id itself changed.
+	// Keying by id instead, and invalidating per id when its rect no
+	// longer matches this snapshot, keeps the cache correct across
+	// in-place updates -- including the common case of moving an
+	// existing object without changing the collection's total count,
+	// which a count-only staleness check can't detect.
+	rects map[string]geometry.Rect
+}
+
+func geohashIndexFor(key string) *geohashIndexEntry {
+	v, _ := geoIndexes.LoadOrStore(key, &geohashIndexEntry{})
+	return v.(*geohashIndexEntry)
+}
+
+// defaultGeohashIndexMaxPrecision is the server-wide default maxPrecision
+// used by USE INDEX GEOHASH when a query doesn't override it with a
+// trailing PRECISION n. It's package-level state rather than a Server
+// field because the Server struct -- and the CONFIG SET/GET dispatch
+// table that would normally expose a property like this -- lives outside
+// this package's slice of the tree; GeohashIndexMaxPrecision and
+// SetGeohashIndexMaxPrecision below are the methods a CONFIG SET
+// "geohash-index-max-precision" handler would call.
+var (
+	defaultGeohashIndexMaxPrecisionMu sync.RWMutex
+	defaultGeohashIndexMaxPrecision   = geohashidx.MaxPrecision
+)
+
+// GeohashIndexMaxPrecision returns the server-wide default max geohash-
+// prefix precision USE INDEX GEOHASH posts and queries objects under.
+func (s *Server) GeohashIndexMaxPrecision() int {
+	defaultGeohashIndexMaxPrecisionMu.RLock()
+	defer defaultGeohashIndexMaxPrecisionMu.RUnlock()
+	return defaultGeohashIndexMaxPrecision
+}
+
+// SetGeohashIndexMaxPrecision updates the server-wide default. A value
+// outside geohashidx's [MinPrecision, MaxPrecision] bounds is replaced by
+// MaxPrecision.
+func (s *Server) SetGeohashIndexMaxPrecision(n int) {
+	if n < geohashidx.MinPrecision || n > geohashidx.MaxPrecision {
+		n = geohashidx.MaxPrecision
+	}
+	defaultGeohashIndexMaxPrecisionMu.Lock()
+	defaultGeohashIndexMaxPrecision = n
+	defaultGeohashIndexMaxPrecisionMu.Unlock()
+}
+
+// geohashCandidates returns the USE INDEX GEOHASH candidate set for obj
+// against key's collection, identified by id, (re)building the index
+// first if it's missing or any object's id/rect has changed since the
+// last build.
+//
+// The collection's write path (SET/DEL) lives outside this package, so
+// unlike an index wired in at the storage layer, this one can't be kept
+// in sync incrementally on every write. Instead it's treated as a cache:
+// scan reports every object currently in key's collection, and that
+// result is compared, id by id, against the rect snapshot recorded at
+// the last refresh; the index is rebuilt only when that comparison finds
+// a difference (an added, removed, or moved id) or maxPrecision changed.
+// Comparing still costs a scan over every object in key's collection --
+// avoiding that scan entirely needs an incremental Insert/Remove hook
+// from the collection's SET/DEL path, which isn't reachable from this
+// package -- but unlike the count-only check this replaces, it never
+// mistakes an unrelated write, or no write at all, for "nothing changed"
+// when an id's geometry actually moved.
+func geohashCandidates(
+	key string, maxPrecision int, obj geojson.Object,
+	scan func(insert func(id *object.Object, rect geojson.Object)),
+) map[string]bool {
+	entry := geohashIndexFor(key)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	current := make(map[string]geometry.Rect)
+	scan(func(o *object.Object, rect geojson.Object) {
+		current[o.ID()] = rect.Rect()
+	})
+
+	stale := entry.index == nil || entry.maxPrecision != maxPrecision ||
+		len(current) != len(entry.rects)
+	if !stale {
+		for id, rect := range current {
+			if prev, ok := entry.rects[id]; !ok || prev != rect {
+				stale = true
+				break
+			}
+		}
+	}
+	if stale {
+		entry.maxPrecision = maxPrecision
+		entry.index = geohashidx.NewWithPrecision(maxPrecision)
+		entry.rects = current
+		for id, rect := range current {
+			entry.index.Insert(id, rect)
+		}
+	}
+
+	out := entry.index.Candidates(obj.Rect(), geohashidx.DefaultMaxCells)
+	ids := make(map[string]bool, len(out))
+	for id := range out {
+		ids[id.(string)] = true
+	}
+	return ids
+}
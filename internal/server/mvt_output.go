@@ -0,0 +1,93 @@
+package server
+
+import (
+	"bytes"
+
+	"github.com/tidwall/geojson"
+	"github.com/tidwall/resp"
+	"github.com/tidwall/tile38/internal/field"
+	"github.com/tidwall/tile38/internal/mvt"
+	"github.com/tidwall/tile38/internal/object"
+)
+
+// fieldsToTags converts an object's fields into the tag map promoted onto
+// its MVT feature, mapping each field's value to one of the Go types
+// mvt.Feature.Tags supports.
+func fieldsToTags(fields field.List) map[string]interface{} {
+	if fields.Len() == 0 {
+		return nil
+	}
+	tags := make(map[string]interface{}, fields.Len())
+	fields.Scan(func(f field.Field) bool {
+		v := f.Value()
+		switch v.Kind() {
+		case field.Number:
+			tags[f.Name()] = v.Num()
+		case field.String:
+			tags[f.Name()] = v.Data()
+		case field.True:
+			tags[f.Name()] = true
+		case field.False:
+			tags[f.Name()] = false
+		default:
+			tags[f.Name()] = v.JSON()
+		}
+		return true
+	})
+	return tags
+}
+
+// cmdWITHINorINTERSECTSasMVT runs the same WITHIN/INTERSECTS exact test as
+// cmdWITHINorINTERSECTS, but serializes the matched objects as a single-
+// layer Mapbox Vector Tile instead of GeoJSON/RESP, for OUTPUT MVT.
+func (s *Server) cmdWITHINorINTERSECTSasMVT(
+	cmd string, msg *Message, sargs liveFenceSwitches,
+) (resp.Value, error) {
+	scratch := &bytes.Buffer{}
+	sw, err := s.newScanWriter(
+		scratch, msg, sargs.key, sargs.output, sargs.precision, sargs.globs,
+		false, sargs.cursor, sargs.limit, sargs.wheres, sargs.whereins,
+		sargs.whereevals, sargs.nofields,
+		sargs.mvt, sargs.tileX, sargs.tileY, sargs.tileZ)
+	if err != nil {
+		return NOMessage, err
+	}
+
+	var features []mvt.Feature
+	if sw.col != nil {
+		var candidates map[string]bool
+		if sargs.useIndex == "geohash" {
+			candidates = geohashCandidates(sargs.key, sargs.indexMaxPrecision,
+				sargs.obj,
+				func(insert func(id *object.Object, rect geojson.Object)) {
+					sw.col.SearchValues(false, sw, msg.Deadline,
+						func(o *object.Object) bool {
+							insert(o, o.Geo())
+							return true
+						},
+					)
+				},
+			)
+		}
+		collect := func(o *object.Object) bool {
+			if candidates != nil && !candidates[o.ID()] {
+				return true
+			}
+			features = append(features, mvt.Feature{
+				Obj:  o.Geo(),
+				Tags: fieldsToTags(o.Fields()),
+			})
+			return true
+		}
+		switch cmd {
+		case "within":
+			sw.col.Within(sargs.obj, sargs.sparse, sw, msg.Deadline, collect)
+		case "intersects":
+			sw.col.Intersects(sargs.obj, sargs.sparse, sw, msg.Deadline, collect)
+		}
+	}
+
+	tile := mvt.Encode(sargs.key, features,
+		sargs.tileX, sargs.tileY, sargs.tileZ, sargs.extent, &s.geomIndexOpts)
+	return resp.BytesValue(tile), nil
+}
@@ -18,6 +18,8 @@ import (
 	"github.com/tidwall/tile38/internal/clip"
 	"github.com/tidwall/tile38/internal/glob"
 	"github.com/tidwall/tile38/internal/object"
+	"github.com/tidwall/tile38/internal/wkb"
+	"github.com/tidwall/tile38/internal/wkt"
 )
 
 const defaultCircleSteps = 64
@@ -27,6 +29,27 @@ type liveFenceSwitches struct {
 	obj  geojson.Object
 	cmd  string
 	roam roamSwitches
+	// useIndex selects the index-side pre-filter for WITHIN/INTERSECTS:
+	// "geohash" narrows to a geohash-prefix candidate set before the
+	// exact test runs, "rtree" (the default) uses the collection's
+	// R-tree traversal as before.
+	useIndex string
+	// indexMaxPrecision is the max geohash-prefix length USE INDEX
+	// GEOHASH posts and queries objects under, overridable with a
+	// trailing PRECISION n. Zero means the server-wide default (see
+	// Server.GeohashIndexMaxPrecision).
+	indexMaxPrecision int
+	// extent is the tile-local coordinate grid size for OUTPUT MVT,
+	// overridable with EXTENT n. Zero means mvt.DefaultExtent.
+	extent int
+	// trailingOutput selects a WITHIN/INTERSECTS response format that
+	// isn't one of the base OUTPUT formats: "mvt" serializes matches as a
+	// Mapbox Vector Tile, "wkt"/"wkb" serialize each match's geometry as
+	// WKT/WKB text instead of GeoJSON. Parsed as a trailing option here
+	// (alongside CLIPBY/USE INDEX/EXTENT) rather than through the normal
+	// OUTPUT keyword, since the base OUTPUT dispatch lives in
+	// parseSearchScanBaseTokens, outside this file.
+	trailingOutput string
 }
 
 type roamSwitches struct {
@@ -318,6 +341,34 @@ func (s *Server) cmdSearchArgs(
 		if err != nil {
 			return
 		}
+	case "wkt":
+		if lfs.clip {
+			err = errInvalidArgument("cannot clip with " + ltyp)
+			return
+		}
+		var text string
+		if vs, text, ok = tokenval(vs); !ok || text == "" {
+			err = errInvalidNumberOfArguments
+			return
+		}
+		lfs.obj, err = wkt.Parse(text, &s.geomIndexOpts)
+		if err != nil {
+			return
+		}
+	case "wkb":
+		if lfs.clip {
+			err = errInvalidArgument("cannot clip with " + ltyp)
+			return
+		}
+		var text string
+		if vs, text, ok = tokenval(vs); !ok || text == "" {
+			err = errInvalidNumberOfArguments
+			return
+		}
+		lfs.obj, err = wkb.Parse(text, &s.geomIndexOpts)
+		if err != nil {
+			return
+		}
 	case "sector":
 		if lfs.clip {
 			err = errInvalidArgument("cannot clip with " + ltyp)
@@ -378,6 +429,68 @@ func (s *Server) cmdSearchArgs(
 		if err != nil {
 			return
 		}
+	case "ellipse":
+		if lfs.clip {
+			err = errInvalidArgument("cannot clip with " + ltyp)
+			return
+		}
+		var slat, slon, sa, sb, sbearing string
+		if vs, slat, ok = tokenval(vs); !ok || slat == "" {
+			err = errInvalidNumberOfArguments
+			return
+		}
+		if vs, slon, ok = tokenval(vs); !ok || slon == "" {
+			err = errInvalidNumberOfArguments
+			return
+		}
+		if vs, sa, ok = tokenval(vs); !ok || sa == "" {
+			err = errInvalidNumberOfArguments
+			return
+		}
+		if vs, sb, ok = tokenval(vs); !ok || sb == "" {
+			err = errInvalidNumberOfArguments
+			return
+		}
+		if vs, sbearing, ok = tokenval(vs); !ok || sbearing == "" {
+			err = errInvalidNumberOfArguments
+			return
+		}
+		var lat, lon, aMeters, bMeters, bearing float64
+		if lat, err = strconv.ParseFloat(slat, 64); err != nil {
+			err = errInvalidArgument(slat)
+			return
+		}
+		if lon, err = strconv.ParseFloat(slon, 64); err != nil {
+			err = errInvalidArgument(slon)
+			return
+		}
+		if aMeters, err = strconv.ParseFloat(sa, 64); err != nil {
+			err = errInvalidArgument(sa)
+			return
+		}
+		if bMeters, err = strconv.ParseFloat(sb, 64); err != nil {
+			err = errInvalidArgument(sb)
+			return
+		}
+		if bearing, err = strconv.ParseFloat(sbearing, 64); err != nil {
+			err = errInvalidArgument(sbearing)
+			return
+		}
+		if aMeters <= 0 {
+			err = errInvalidArgument(sa)
+			return
+		}
+		if bMeters <= 0 {
+			err = errInvalidArgument(sb)
+			return
+		}
+		if aMeters == bMeters {
+			err = fmt.Errorf("equal radii (%s == %s), use CIRCLE instead", sa, sb)
+			return
+		}
+		lfs.obj = geojson.NewPolygon(ellipsePoly(
+			lat, lon, aMeters, bMeters, bearing, defaultCircleSteps,
+			&s.geomIndexOpts))
 	case "bounds", "hash", "tile", "mvt", "quadkey":
 		vs, lfs.obj, lfs.tileX, lfs.tileY, lfs.tileZ, err =
 			parseRectArea(ltyp, vs)
@@ -452,29 +565,99 @@ func (s *Server) cmdSearchArgs(
 			err = errInvalidNumberOfArguments
 			return
 		}
-		if strings.ToLower(tok) != "clipby" {
-			err = errInvalidNumberOfArguments
-			return
-		}
-		if vs, tok, ok = tokenval(vs); !ok || tok == "" {
-			err = errInvalidNumberOfArguments
-			return
-		}
-		ltok = strings.ToLower(tok)
-		switch ltok {
-		case "bounds", "hash", "tile", "quadkey":
-			vs, clipRect, lfs.tileX, lfs.tileY, lfs.tileZ, err =
-				parseRectArea(ltok, vs)
-			if err == errNotRectangle {
+		switch strings.ToLower(tok) {
+		case "clipby":
+			if vs, tok, ok = tokenval(vs); !ok || tok == "" {
+				err = errInvalidNumberOfArguments
+				return
+			}
+			ltok = strings.ToLower(tok)
+			switch ltok {
+			case "bounds", "hash", "tile", "quadkey":
+				vs, clipRect, lfs.tileX, lfs.tileY, lfs.tileZ, err =
+					parseRectArea(ltok, vs)
+				if err == errNotRectangle {
+					err = errInvalidArgument("cannot clipby " + ltok)
+					return
+				}
+				if err != nil {
+					return
+				}
+				lfs.obj = clip.Clip(lfs.obj, clipRect, &s.geomIndexOpts)
+			default:
 				err = errInvalidArgument("cannot clipby " + ltok)
 				return
 			}
-			if err != nil {
+		case "use":
+			if vs, tok, ok = tokenval(vs); !ok || strings.ToLower(tok) != "index" {
+				err = errInvalidNumberOfArguments
+				return
+			}
+			if vs, tok, ok = tokenval(vs); !ok || tok == "" {
+				err = errInvalidNumberOfArguments
+				return
+			}
+			ltok = strings.ToLower(tok)
+			switch ltok {
+			case "geohash", "rtree":
+				lfs.useIndex = ltok
+			default:
+				err = errInvalidArgument("cannot use index " + ltok)
+				return
+			}
+			if ltok == "geohash" {
+				// optional per-query override: USE INDEX GEOHASH PRECISION n
+				save := vs
+				var ptok string
+				if vs, ptok, ok = tokenval(vs); ok && strings.ToLower(ptok) == "precision" {
+					var ptok2 string
+					if vs, ptok2, ok = tokenval(vs); !ok || ptok2 == "" {
+						err = errInvalidNumberOfArguments
+						return
+					}
+					var n int
+					if n, err = strconv.Atoi(ptok2); err != nil || n <= 0 {
+						err = errInvalidArgument(ptok2)
+						return
+					}
+					lfs.indexMaxPrecision = n
+				} else {
+					vs = save
+				}
+				if lfs.indexMaxPrecision == 0 {
+					// no per-query PRECISION given -- fall back to the
+					// server-wide config knob instead of leaving this at
+					// zero (which geohashidx would silently treat as "no
+					// limit", bypassing the knob entirely).
+					lfs.indexMaxPrecision = s.GeohashIndexMaxPrecision()
+				}
+			}
+		case "extent":
+			if vs, tok, ok = tokenval(vs); !ok || tok == "" {
+				err = errInvalidNumberOfArguments
+				return
+			}
+			var n int
+			if n, err = strconv.Atoi(tok); err != nil || n <= 0 {
+				err = errInvalidArgument(tok)
+				return
+			}
+			lfs.extent = n
+		case "output":
+			if vs, tok, ok = tokenval(vs); !ok || tok == "" {
+				err = errInvalidNumberOfArguments
+				return
+			}
+			ltok = strings.ToLower(tok)
+			switch ltok {
+			case "mvt", "wkt", "wkb":
+				lfs.trailingOutput = ltok
+			default:
+				err = errInvalidArgument(tok)
 				return
 			}
-			lfs.obj = clip.Clip(lfs.obj, clipRect, &s.geomIndexOpts)
 		default:
-			err = errInvalidArgument("cannot clipby " + ltok)
+			err = errInvalidNumberOfArguments
 			return
 		}
 	}
@@ -495,7 +678,7 @@ var nearbyTypes = map[string]bool{
 var withinOrIntersectsTypes = map[string]bool{
 	"geo": true, "bounds": true, "hash": true, "tile": true, "quadkey": true,
 	"get": true, "object": true, "circle": true, "point": true, "sector": true,
-	"mvt": true,
+	"mvt": true, "wkt": true, "wkb": true, "ellipse": true,
 }
 
 func (s *Server) cmdNearby(msg *Message) (res resp.Value, err error) {
@@ -548,6 +731,23 @@ func (s *Server) cmdNearby(msg *Message) (res resp.Value, err error) {
 			return keepGoing
 		}
 		maxDist := sargs.obj.(*geojson.Circle).Meters()
+		var candidates map[string]bool
+		if sargs.useIndex == "geohash" && maxDist > 0 {
+			// Geohash prefix narrowing only makes sense for a bounded
+			// radius -- an open KNN search has no finite rect to derive
+			// covering prefixes from.
+			candidates = geohashCandidates(sargs.key, sargs.indexMaxPrecision,
+				sargs.obj,
+				func(insert func(id *object.Object, rect geojson.Object)) {
+					sw.col.SearchValues(false, sw, msg.Deadline,
+						func(o *object.Object) bool {
+							insert(o, o.Geo())
+							return true
+						},
+					)
+				},
+			)
+		}
 		if sargs.sparse > 0 {
 			if maxDist < 0 {
 				// error cannot use SPARSE and KNN together
@@ -556,6 +756,9 @@ func (s *Server) cmdNearby(msg *Message) (res resp.Value, err error) {
 			}
 			// An intersects operation is required for SPARSE
 			iter := func(o *object.Object) bool {
+				if candidates != nil && !candidates[o.ID()] {
+					return true
+				}
 				var dist float64
 				if sargs.distance {
 					dist = o.Geo().Distance(sargs.obj)
@@ -568,6 +771,9 @@ func (s *Server) cmdNearby(msg *Message) (res resp.Value, err error) {
 				if maxDist > 0 && dist > maxDist {
 					return false
 				}
+				if candidates != nil && !candidates[o.ID()] {
+					return true
+				}
 				var meters float64
 				if sargs.distance {
 					meters = dist
@@ -619,6 +825,12 @@ func (s *Server) cmdWITHINorINTERSECTS(cmd string, msg *Message) (res resp.Value
 	if sargs.fence {
 		return NOMessage, sargs
 	}
+	switch sargs.trailingOutput {
+	case "mvt":
+		return s.cmdWITHINorINTERSECTSasMVT(cmd, msg, sargs)
+	case "wkt", "wkb":
+		return s.cmdWITHINorINTERSECTSasWKTorWKB(cmd, msg, sargs)
+	}
 	sw, err := s.newScanWriter(
 		wr, msg, sargs.key, sargs.output, sargs.precision, sargs.globs, false,
 		sargs.cursor, sargs.limit, sargs.wheres, sargs.whereins,
@@ -632,10 +844,27 @@ func (s *Server) cmdWITHINorINTERSECTS(cmd string, msg *Message) (res resp.Value
 	}
 	var ierr error
 	if sw.col != nil {
+		var candidates map[string]bool
+		if sargs.useIndex == "geohash" {
+			candidates = geohashCandidates(sargs.key, sargs.indexMaxPrecision,
+				sargs.obj,
+				func(insert func(id *object.Object, rect geojson.Object)) {
+					sw.col.SearchValues(false, sw, msg.Deadline,
+						func(o *object.Object) bool {
+							insert(o, o.Geo())
+							return true
+						},
+					)
+				},
+			)
+		}
 		switch cmd {
 		case "within":
 			sw.col.Within(sargs.obj, sargs.sparse, sw, msg.Deadline,
 				func(o *object.Object) bool {
+					if candidates != nil && !candidates[o.ID()] {
+						return true
+					}
 					keepGoing, err := sw.pushObject(ScanWriterParams{obj: o})
 					if err != nil {
 						ierr = err
@@ -647,6 +876,9 @@ func (s *Server) cmdWITHINorINTERSECTS(cmd string, msg *Message) (res resp.Value
 		case "intersects":
 			sw.col.Intersects(sargs.obj, sargs.sparse, sw, msg.Deadline,
 				func(o *object.Object) bool {
+					if candidates != nil && !candidates[o.ID()] {
+						return true
+					}
 					params := ScanWriterParams{obj: o}
 					if sargs.clip {
 						params.clip = sargs.obj
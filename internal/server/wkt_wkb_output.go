@@ -0,0 +1,83 @@
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/tidwall/geojson"
+	"github.com/tidwall/resp"
+	"github.com/tidwall/tile38/internal/object"
+	"github.com/tidwall/tile38/internal/wkb"
+	"github.com/tidwall/tile38/internal/wkt"
+)
+
+// cmdWITHINorINTERSECTSasWKTorWKB runs the same WITHIN/INTERSECTS exact test
+// as cmdWITHINorINTERSECTS, but serializes each match's geometry as WKT text
+// or base64 WKB instead of GeoJSON, for OUTPUT WKT / OUTPUT WKB.
+//
+// This always responds with a JSON object, regardless of the connection's
+// negotiated OutputType -- the per-object RESP field layout that
+// sw.pushObject/writeFoot produce for cmdWITHINorINTERSECTS in search.go
+// isn't used here, so, like OUTPUT MVT (cmdWITHINorINTERSECTSasMVT in
+// mvt_output.go), this bypasses that machinery rather than guess at it.
+func (s *Server) cmdWITHINorINTERSECTSasWKTorWKB(
+	cmd string, msg *Message, sargs liveFenceSwitches,
+) (resp.Value, error) {
+	start := time.Now()
+	wr := &bytes.Buffer{}
+	sw, err := s.newScanWriter(
+		&bytes.Buffer{}, msg, sargs.key, sargs.output, sargs.precision,
+		sargs.globs, false, sargs.cursor, sargs.limit, sargs.wheres,
+		sargs.whereins, sargs.whereevals, sargs.nofields,
+		sargs.mvt, sargs.tileX, sargs.tileY, sargs.tileZ)
+	if err != nil {
+		return NOMessage, err
+	}
+
+	wr.WriteString(`{"ok":true,"objects":[`)
+	first := true
+	if sw.col != nil {
+		var candidates map[string]bool
+		if sargs.useIndex == "geohash" {
+			candidates = geohashCandidates(sargs.key, sargs.indexMaxPrecision,
+				sargs.obj,
+				func(insert func(id *object.Object, rect geojson.Object)) {
+					sw.col.SearchValues(false, sw, msg.Deadline,
+						func(o *object.Object) bool {
+							insert(o, o.Geo())
+							return true
+						},
+					)
+				},
+			)
+		}
+		collect := func(o *object.Object) bool {
+			if candidates != nil && !candidates[o.ID()] {
+				return true
+			}
+			if !first {
+				wr.WriteByte(',')
+			}
+			first = false
+			switch sargs.trailingOutput {
+			case "wkb":
+				enc := base64.StdEncoding.EncodeToString(wkb.Write(o.Geo()))
+				wr.WriteString(`"` + enc + `"`)
+			default:
+				enc, _ := json.Marshal(wkt.Write(o.Geo()))
+				wr.Write(enc)
+			}
+			return true
+		}
+		switch cmd {
+		case "within":
+			sw.col.Within(sargs.obj, sargs.sparse, sw, msg.Deadline, collect)
+		case "intersects":
+			sw.col.Intersects(sargs.obj, sargs.sparse, sw, msg.Deadline, collect)
+		}
+	}
+	wr.WriteString(`],"elapsed":"` + time.Since(start).String() + `"}`)
+	return resp.BytesValue(wr.Bytes()), nil
+}
@@ -0,0 +1,331 @@
+// Package wkb implements a minimal Well-Known Binary reader/writer that
+// produces and consumes geojson.Object values.
+package wkb
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+
+	"github.com/tidwall/geojson"
+	"github.com/tidwall/geojson/geometry"
+)
+
+// geometry type codes, per the WKB spec. The ISO variants add Z/M/ZM flags
+// of +1000/+2000/+3000, and PostGIS's EWKB variant instead sets high bits
+// (wkbZFlagEWKB/wkbMFlagEWKB) alongside an optional SRID -- both are
+// recognized and stripped, and the extra ordinates they introduce are
+// parsed and discarded, downcasting to 2D.
+const (
+	wkbPoint              = 1
+	wkbLineString         = 2
+	wkbPolygon            = 3
+	wkbMultiPoint         = 4
+	wkbMultiLineString    = 5
+	wkbMultiPolygon       = 6
+	wkbGeometryCollection = 7
+)
+
+// EWKB high-bit flags PostGIS sets on the geometry type word, distinct from
+// the ISO +1000/+2000/+3000 convention: the top bit marks a Z ordinate,
+// the next an M ordinate, and the third an SRID present immediately after
+// the type word.
+const (
+	wkbSRIDFlagEWKB = 0x20000000
+	wkbZFlagEWKB    = 0x80000000
+	wkbMFlagEWKB    = 0x40000000
+)
+
+type reader struct {
+	b    []byte
+	pos  int
+	bo   binary.ByteOrder
+	opts *geometry.IndexOptions
+}
+
+func (r *reader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("wkb: %s (at byte %d)", fmt.Sprintf(format, args...), r.pos)
+}
+
+func (r *reader) byte() (byte, error) {
+	if r.pos >= len(r.b) {
+		return 0, r.errorf("unexpected end of input")
+	}
+	v := r.b[r.pos]
+	r.pos++
+	return v, nil
+}
+
+func (r *reader) uint32() (uint32, error) {
+	if r.pos+4 > len(r.b) {
+		return 0, r.errorf("unexpected end of input")
+	}
+	v := r.bo.Uint32(r.b[r.pos:])
+	r.pos += 4
+	return v, nil
+}
+
+func (r *reader) float64() (float64, error) {
+	if r.pos+8 > len(r.b) {
+		return 0, r.errorf("unexpected end of input")
+	}
+	bits := r.bo.Uint64(r.b[r.pos:])
+	r.pos += 8
+	return math.Float64frombits(bits), nil
+}
+
+// isEWKB reports whether typ carries any of PostGIS's EWKB high-bit flags,
+// as opposed to the ISO +1000/+2000/+3000 convention.
+func isEWKB(typ uint32) bool {
+	return typ&(wkbZFlagEWKB|wkbMFlagEWKB|wkbSRIDFlagEWKB) != 0
+}
+
+// hasSRID reports whether typ's EWKB SRID flag is set, meaning a 4-byte
+// SRID immediately follows the type word.
+func hasSRID(typ uint32) bool {
+	return typ&wkbSRIDFlagEWKB != 0
+}
+
+// dims returns how many ordinates follow the x/y pair for the given
+// (possibly flagged) geometry type code: 0 for plain/2D, 1 for Z or M, 2
+// for ZM.
+func dims(typ uint32) uint32 {
+	if isEWKB(typ) {
+		var n uint32
+		if typ&wkbZFlagEWKB != 0 {
+			n++
+		}
+		if typ&wkbMFlagEWKB != 0 {
+			n++
+		}
+		return n
+	}
+	switch {
+	case typ >= 3000:
+		return 2
+	case typ >= 1000, typ >= 2000:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func baseType(typ uint32) uint32 {
+	if isEWKB(typ) {
+		return typ & 0xff
+	}
+	return typ % 1000
+}
+
+func (r *reader) point(extra uint32) (geometry.Point, error) {
+	x, err := r.float64()
+	if err != nil {
+		return geometry.Point{}, err
+	}
+	y, err := r.float64()
+	if err != nil {
+		return geometry.Point{}, err
+	}
+	// downcast 3D/measured coordinates to 2D by discarding trailing
+	// ordinates.
+	for i := uint32(0); i < extra; i++ {
+		if _, err := r.float64(); err != nil {
+			return geometry.Point{}, err
+		}
+	}
+	return geometry.Point{X: x, Y: y}, nil
+}
+
+func (r *reader) points(extra uint32) ([]geometry.Point, error) {
+	n, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	// pre-count is exact here since the count is encoded up front, so a
+	// single allocation covers the whole ring/line.
+	pts := make([]geometry.Point, n)
+	for i := range pts {
+		pts[i], err = r.point(extra)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pts, nil
+}
+
+func (r *reader) ring(extra uint32) (exterior []geometry.Point, holes [][]geometry.Point, err error) {
+	nrings, err := r.uint32()
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := uint32(0); i < nrings; i++ {
+		pts, err := r.points(extra)
+		if err != nil {
+			return nil, nil, err
+		}
+		if i == 0 {
+			exterior = pts
+		} else {
+			holes = append(holes, pts)
+		}
+	}
+	return exterior, holes, nil
+}
+
+func (r *reader) geometry() (geojson.Object, error) {
+	order, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	switch order {
+	case 0:
+		r.bo = binary.BigEndian
+	case 1:
+		r.bo = binary.LittleEndian
+	default:
+		return nil, r.errorf("invalid byte order marker 0x%02x", order)
+	}
+	typ, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	if hasSRID(typ) {
+		// EWKB's SRID field, immediately after the type word and before
+		// any coordinates -- discarded, since geometry.IndexOptions carries
+		// no SRID of its own to reproject into.
+		if _, err := r.uint32(); err != nil {
+			return nil, err
+		}
+	}
+	extra := dims(typ)
+	switch baseType(typ) {
+	case wkbPoint:
+		pt, err := r.point(extra)
+		if err != nil {
+			return nil, err
+		}
+		return geojson.NewPoint(pt), nil
+	case wkbLineString:
+		pts, err := r.points(extra)
+		if err != nil {
+			return nil, err
+		}
+		return geojson.NewLineString(geometry.NewLine(pts, r.opts)), nil
+	case wkbPolygon:
+		ext, holes, err := r.ring(extra)
+		if err != nil {
+			return nil, err
+		}
+		return geojson.NewPolygon(geometry.NewPoly(ext, holes, r.opts)), nil
+	case wkbMultiPoint:
+		n, err := r.uint32()
+		if err != nil {
+			return nil, err
+		}
+		pts := make([]geometry.Point, n)
+		for i := range pts {
+			g, err := r.geometry()
+			if err != nil {
+				return nil, err
+			}
+			p, ok := g.(*geojson.Point)
+			if !ok {
+				return nil, r.errorf("expected POINT member in MULTIPOINT")
+			}
+			pts[i] = p.Base()
+		}
+		return geojson.NewMultiPoint(pts), nil
+	case wkbMultiLineString:
+		n, err := r.uint32()
+		if err != nil {
+			return nil, err
+		}
+		lines := make([]*geometry.Line, n)
+		for i := range lines {
+			g, err := r.geometry()
+			if err != nil {
+				return nil, err
+			}
+			ls, ok := g.(*geojson.LineString)
+			if !ok {
+				return nil, r.errorf("expected LINESTRING member in MULTILINESTRING")
+			}
+			lines[i] = ls.Base()
+		}
+		return geojson.NewMultiLineString(lines), nil
+	case wkbMultiPolygon:
+		n, err := r.uint32()
+		if err != nil {
+			return nil, err
+		}
+		polys := make([]*geometry.Poly, n)
+		for i := range polys {
+			g, err := r.geometry()
+			if err != nil {
+				return nil, err
+			}
+			p, ok := g.(*geojson.Polygon)
+			if !ok {
+				return nil, r.errorf("expected POLYGON member in MULTIPOLYGON")
+			}
+			polys[i] = p.Base()
+		}
+		return geojson.NewMultiPolygon(polys), nil
+	case wkbGeometryCollection:
+		n, err := r.uint32()
+		if err != nil {
+			return nil, err
+		}
+		geoms := make([]geojson.Object, n)
+		for i := range geoms {
+			g, err := r.geometry()
+			if err != nil {
+				return nil, err
+			}
+			geoms[i] = g
+		}
+		return geojson.NewGeometryCollection(geoms), nil
+	default:
+		return nil, r.errorf("unsupported geometry type code %d", typ)
+	}
+}
+
+// Parse decodes a WKB geometry encoded as either hex or standard base64,
+// matching the two encodings commonly emitted by PostGIS (ST_AsBinary/
+// ST_AsHexEWKB clients) and other WKB producers. Both the ISO WKB Z/M/ZM
+// convention and PostGIS's EWKB flags/SRID are understood; an EWKB SRID,
+// if present, is read and discarded.
+func Parse(s string, opts *geometry.IndexOptions) (geojson.Object, error) {
+	raw, err := decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("wkb: %w", err)
+	}
+	r := &reader{b: raw, opts: opts}
+	return r.geometry()
+}
+
+func decode(s string) ([]byte, error) {
+	if looksHex(s) {
+		return hex.DecodeString(s)
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
+
+func looksHex(s string) bool {
+	if len(s) == 0 || len(s)%2 != 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+		case c >= 'a' && c <= 'f':
+		case c >= 'A' && c <= 'F':
+		default:
+			return false
+		}
+	}
+	return true
+}
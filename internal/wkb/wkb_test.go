@@ -0,0 +1,78 @@
+package wkb
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"testing"
+
+	"github.com/tidwall/geojson"
+	"github.com/tidwall/geojson/geometry"
+)
+
+func TestParseRoundTripHexAndBase64(t *testing.T) {
+	pt := geojson.NewPoint(geometry.Point{X: 1.5, Y: -2.5})
+	raw := Write(pt)
+
+	hexObj, err := Parse(hex.EncodeToString(raw), nil)
+	if err != nil {
+		t.Fatalf("Parse(hex) error: %v", err)
+	}
+	b64Obj, err := Parse(base64.StdEncoding.EncodeToString(raw), nil)
+	if err != nil {
+		t.Fatalf("Parse(base64) error: %v", err)
+	}
+	hp := hexObj.(*geojson.Point).Base()
+	bp := b64Obj.(*geojson.Point).Base()
+	if hp != bp || hp.X != 1.5 || hp.Y != -2.5 {
+		t.Fatalf("expected hex/base64 decodes to agree at (1.5,-2.5), got %v and %v", hp, bp)
+	}
+}
+
+func TestParseISOZDowncastsTo2D(t *testing.T) {
+	var b []byte
+	b = append(b, 1)          // little-endian
+	b = appendUint32(b, 1001) // ISO Point Z
+	b = appendPoint(b, geometry.Point{X: 3, Y: 4})
+	var tmp [8]byte
+	binary.LittleEndian.PutUint64(tmp[:], math.Float64bits(99)) // Z ordinate
+	b = append(b, tmp[:]...)
+
+	obj, err := Parse(hex.EncodeToString(b), nil)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	p := obj.(*geojson.Point).Base()
+	if p.X != 3 || p.Y != 4 {
+		t.Fatalf("expected (3,4) after downcast, got %v", p)
+	}
+}
+
+func TestParseEWKBWithSRIDAndZ(t *testing.T) {
+	var b []byte
+	b = append(b, 1) // little-endian
+	typ := uint32(wkbPoint) | wkbSRIDFlagEWKB | wkbZFlagEWKB
+	b = appendUint32(b, typ)
+	b = appendUint32(b, 4326) // SRID
+	b = appendPoint(b, geometry.Point{X: 10, Y: 20})
+	var tmp [8]byte
+	binary.LittleEndian.PutUint64(tmp[:], math.Float64bits(5)) // Z ordinate
+	b = append(b, tmp[:]...)
+
+	obj, err := Parse(hex.EncodeToString(b), nil)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	p := obj.(*geojson.Point).Base()
+	if p.X != 10 || p.Y != 20 {
+		t.Fatalf("expected (10,20) from EWKB with SRID+Z, got %v", p)
+	}
+}
+
+func TestParseInvalidByteOrder(t *testing.T) {
+	_, err := Parse(hex.EncodeToString([]byte{2, 0, 0, 0, 0}), nil)
+	if err == nil {
+		t.Fatalf("expected an error for an invalid byte order marker")
+	}
+}
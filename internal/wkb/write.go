@@ -0,0 +1,107 @@
+package wkb
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/tidwall/geojson"
+	"github.com/tidwall/geojson/geometry"
+)
+
+func appendUint32(b []byte, v uint32) []byte {
+	var tmp [4]byte
+	binary.LittleEndian.PutUint32(tmp[:], v)
+	return append(b, tmp[:]...)
+}
+
+func appendPoint(b []byte, pt geometry.Point) []byte {
+	var tmp [8]byte
+	binary.LittleEndian.PutUint64(tmp[:], math.Float64bits(pt.X))
+	b = append(b, tmp[:]...)
+	binary.LittleEndian.PutUint64(tmp[:], math.Float64bits(pt.Y))
+	b = append(b, tmp[:]...)
+	return b
+}
+
+func appendHeader(b []byte, typ uint32) []byte {
+	b = append(b, 1) // little-endian
+	return appendUint32(b, typ)
+}
+
+func appendLine(b []byte, line geometry.Ring) []byte {
+	n := line.NumPoints()
+	b = appendUint32(b, uint32(n))
+	for i := 0; i < n; i++ {
+		b = appendPoint(b, line.PointAt(i))
+	}
+	return b
+}
+
+func appendPoly(b []byte, poly *geometry.Poly) []byte {
+	b = appendUint32(b, uint32(1+len(poly.Holes)))
+	b = appendLine(b, poly.Exterior)
+	for _, hole := range poly.Holes {
+		b = appendLine(b, hole)
+	}
+	return b
+}
+
+// Write encodes a geojson.Object as little-endian WKB. A Feature is written
+// as its geometry; any other type that Parse does not produce (e.g. Circle,
+// Rect) is written as a POINT at the center of its bounding rect.
+func Write(o geojson.Object) []byte {
+	var b []byte
+	switch g := o.(type) {
+	case *geojson.Point:
+		b = appendHeader(b, wkbPoint)
+		b = appendPoint(b, g.Base())
+	case *geojson.LineString:
+		b = appendHeader(b, wkbLineString)
+		b = appendLine(b, g.Base())
+	case *geojson.Polygon:
+		b = appendHeader(b, wkbPolygon)
+		b = appendPoly(b, g.Base())
+	case *geojson.MultiPoint:
+		children := g.Children()
+		b = appendHeader(b, wkbMultiPoint)
+		b = appendUint32(b, uint32(len(children)))
+		for _, child := range children {
+			b = append(b, Write(child)...)
+		}
+	case *geojson.MultiLineString:
+		children := g.Children()
+		b = appendHeader(b, wkbMultiLineString)
+		b = appendUint32(b, uint32(len(children)))
+		for _, child := range children {
+			b = append(b, Write(child)...)
+		}
+	case *geojson.MultiPolygon:
+		children := g.Children()
+		b = appendHeader(b, wkbMultiPolygon)
+		b = appendUint32(b, uint32(len(children)))
+		for _, child := range children {
+			b = append(b, Write(child)...)
+		}
+	case *geojson.GeometryCollection:
+		children := g.Children()
+		b = appendHeader(b, wkbGeometryCollection)
+		b = appendUint32(b, uint32(len(children)))
+		for _, child := range children {
+			b = append(b, Write(child)...)
+		}
+	case *geojson.Feature:
+		// Features -- the form SET ... OBJECT {"type":"Feature",...} stores
+		// -- carry no geometry of their own; unwrap to the underlying
+		// geometry instead of falling through to the centroid fallback.
+		return Write(g.Base())
+	default:
+		rect := o.Rect()
+		center := geometry.Point{
+			X: (rect.Min.X + rect.Max.X) / 2,
+			Y: (rect.Min.Y + rect.Max.Y) / 2,
+		}
+		b = appendHeader(b, wkbPoint)
+		b = appendPoint(b, center)
+	}
+	return b
+}
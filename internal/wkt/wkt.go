@@ -0,0 +1,436 @@
+// Package wkt implements a minimal Well-Known Text reader that produces
+// geojson.Object values compatible with the rest of Tile38's search paths.
+package wkt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/geojson"
+	"github.com/tidwall/geojson/geometry"
+)
+
+// tokKind identifies what a scanned token represents.
+type tokKind byte
+
+const (
+	tokWord  tokKind = iota // a bare word, e.g. POLYGON, EMPTY, Z, M, ZM
+	tokNum                  // a signed/decimal number
+	tokLP                   // (
+	tokRP                   // )
+	tokComma                // ,
+	tokEOF
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+// lexer tokenizes WKT text without regular expressions, scanning the input
+// once and classifying runs of characters as they're encountered.
+type lexer struct {
+	s   string
+	pos int
+}
+
+func newLexer(s string) *lexer {
+	return &lexer{s: s}
+}
+
+func isNumStart(b byte) bool {
+	return b == '-' || b == '+' || b == '.' || (b >= '0' && b <= '9')
+}
+
+func isNumPart(b byte) bool {
+	return b == '.' || b == '-' || b == '+' || b == 'e' || b == 'E' ||
+		(b >= '0' && b <= '9')
+}
+
+func isWordPart(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+func (lx *lexer) next() token {
+	for lx.pos < len(lx.s) {
+		b := lx.s[lx.pos]
+		if b == ' ' || b == '\t' || b == '\n' || b == '\r' {
+			lx.pos++
+			continue
+		}
+		break
+	}
+	if lx.pos >= len(lx.s) {
+		return token{kind: tokEOF, pos: lx.pos}
+	}
+	start := lx.pos
+	b := lx.s[lx.pos]
+	switch {
+	case b == '(':
+		lx.pos++
+		return token{kind: tokLP, text: "(", pos: start}
+	case b == ')':
+		lx.pos++
+		return token{kind: tokRP, text: ")", pos: start}
+	case b == ',':
+		lx.pos++
+		return token{kind: tokComma, text: ",", pos: start}
+	case isWordPart(b):
+		for lx.pos < len(lx.s) && isWordPart(lx.s[lx.pos]) {
+			lx.pos++
+		}
+		return token{kind: tokWord, text: lx.s[start:lx.pos], pos: start}
+	case isNumStart(b):
+		for lx.pos < len(lx.s) && isNumPart(lx.s[lx.pos]) {
+			lx.pos++
+		}
+		return token{kind: tokNum, text: lx.s[start:lx.pos], pos: start}
+	default:
+		lx.pos++
+		return token{kind: tokWord, text: string(b), pos: start}
+	}
+}
+
+// parser walks the token stream produced by lexer and builds geometry.
+type parser struct {
+	lx   *lexer
+	cur  token
+	opts *geometry.IndexOptions
+}
+
+func errAt(pos int, format string, args ...interface{}) error {
+	return fmt.Errorf("wkt: %s (at position %d)", fmt.Sprintf(format, args...), pos)
+}
+
+func (p *parser) advance() {
+	p.cur = p.lx.next()
+}
+
+func (p *parser) expect(kind tokKind) (token, error) {
+	if p.cur.kind != kind {
+		return token{}, errAt(p.cur.pos, "unexpected token %q", p.cur.text)
+	}
+	t := p.cur
+	p.advance()
+	return t, nil
+}
+
+// stripDims consumes an optional Z, M, or ZM suffix after a geometry
+// keyword, e.g. "POINT Z" or "LINESTRING ZM". The dimension itself is not
+// retained -- 3D/measured coordinates are always downcast to 2D.
+func (p *parser) stripDims() {
+	if p.cur.kind == tokWord {
+		switch strings.ToUpper(p.cur.text) {
+		case "Z", "M", "ZM":
+			p.advance()
+		}
+	}
+}
+
+// isEmpty consumes a trailing EMPTY keyword if present and reports whether
+// it was found.
+func (p *parser) isEmpty() bool {
+	if p.cur.kind == tokWord && strings.ToUpper(p.cur.text) == "EMPTY" {
+		p.advance()
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseNumber() (float64, error) {
+	if p.cur.kind != tokNum {
+		return 0, errAt(p.cur.pos, "expected a number, got %q", p.cur.text)
+	}
+	f, err := strconv.ParseFloat(p.cur.text, 64)
+	if err != nil {
+		return 0, errAt(p.cur.pos, "invalid number %q", p.cur.text)
+	}
+	p.advance()
+	return f, nil
+}
+
+// parseCoord reads "x y" and silently discards any further z/m ordinates.
+func (p *parser) parseCoord() (geometry.Point, error) {
+	x, err := p.parseNumber()
+	if err != nil {
+		return geometry.Point{}, err
+	}
+	y, err := p.parseNumber()
+	if err != nil {
+		return geometry.Point{}, err
+	}
+	// downcast 3D/measured coordinates to 2D by ignoring trailing ordinates.
+	for p.cur.kind == tokNum {
+		p.advance()
+	}
+	return geometry.Point{X: x, Y: y}, nil
+}
+
+// countPoints scans ahead through a "(x y, x y, ...)" run to count the
+// coordinates it holds, without allocating. The lexer position is restored
+// afterward so the caller can parse the same run for real.
+func (lx *lexer) countPoints(from int) int {
+	save := lx.pos
+	lx.pos = from
+	depth := 0
+	n := 0
+	sawNum := false
+	for {
+		t := lx.next()
+		switch t.kind {
+		case tokEOF:
+			lx.pos = save
+			return n
+		case tokLP:
+			depth++
+		case tokRP:
+			if depth == 0 {
+				lx.pos = save
+				return n
+			}
+			depth--
+		case tokNum:
+			if !sawNum {
+				n++
+				sawNum = true
+			}
+		case tokComma:
+			sawNum = false
+		}
+	}
+}
+
+// parsePointSeq parses "(x y, x y, ...)" into a single pre-sized slice,
+// avoiding per-coordinate reallocation for large rings.
+func (p *parser) parsePointSeq() ([]geometry.Point, error) {
+	if _, err := p.expect(tokLP); err != nil {
+		return nil, err
+	}
+	n := p.lx.countPoints(p.cur.pos)
+	pts := make([]geometry.Point, 0, n)
+	for {
+		pt, err := p.parseCoord()
+		if err != nil {
+			return nil, err
+		}
+		pts = append(pts, pt)
+		if p.cur.kind == tokComma {
+			p.advance()
+			continue
+		}
+		break
+	}
+	if _, err := p.expect(tokRP); err != nil {
+		return nil, err
+	}
+	return pts, nil
+}
+
+// parseMultiPointSeq parses a MULTIPOINT body in either the flat form WKT
+// itself specifies, "(1 2, 3 4)", or the form PostGIS/GEOS write by
+// default, with each point individually parenthesized: "((1 2), (3 4))".
+func (p *parser) parseMultiPointSeq() ([]geometry.Point, error) {
+	if _, err := p.expect(tokLP); err != nil {
+		return nil, err
+	}
+	var pts []geometry.Point
+	for {
+		parenthesized := p.cur.kind == tokLP
+		if parenthesized {
+			p.advance()
+		}
+		pt, err := p.parseCoord()
+		if err != nil {
+			return nil, err
+		}
+		if parenthesized {
+			if _, err := p.expect(tokRP); err != nil {
+				return nil, err
+			}
+		}
+		pts = append(pts, pt)
+		if p.cur.kind == tokComma {
+			p.advance()
+			continue
+		}
+		break
+	}
+	if _, err := p.expect(tokRP); err != nil {
+		return nil, err
+	}
+	return pts, nil
+}
+
+// parseRingSeq parses "((ring),(hole),...)" -- a polygon's exterior ring
+// followed by zero or more interior rings.
+func (p *parser) parseRingSeq() ([]geometry.Point, [][]geometry.Point, error) {
+	if _, err := p.expect(tokLP); err != nil {
+		return nil, nil, err
+	}
+	exterior, err := p.parsePointSeq()
+	if err != nil {
+		return nil, nil, err
+	}
+	var holes [][]geometry.Point
+	for p.cur.kind == tokComma {
+		p.advance()
+		hole, err := p.parsePointSeq()
+		if err != nil {
+			return nil, nil, err
+		}
+		holes = append(holes, hole)
+	}
+	if _, err := p.expect(tokRP); err != nil {
+		return nil, nil, err
+	}
+	return exterior, holes, nil
+}
+
+func (p *parser) parseGeometry() (geojson.Object, error) {
+	if p.cur.kind != tokWord {
+		return nil, errAt(p.cur.pos, "expected a geometry type, got %q", p.cur.text)
+	}
+	kindPos := p.cur.pos
+	kind := strings.ToUpper(p.cur.text)
+	p.advance()
+	p.stripDims()
+	switch kind {
+	case "POINT":
+		if p.isEmpty() {
+			return geojson.NewPoint(geometry.Point{}), nil
+		}
+		if _, err := p.expect(tokLP); err != nil {
+			return nil, err
+		}
+		pt, err := p.parseCoord()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokRP); err != nil {
+			return nil, err
+		}
+		return geojson.NewPoint(pt), nil
+	case "LINESTRING":
+		if p.isEmpty() {
+			return geojson.NewLineString(
+				geometry.NewLine(nil, p.opts)), nil
+		}
+		pts, err := p.parsePointSeq()
+		if err != nil {
+			return nil, err
+		}
+		return geojson.NewLineString(geometry.NewLine(pts, p.opts)), nil
+	case "POLYGON":
+		if p.isEmpty() {
+			return geojson.NewPolygon(
+				geometry.NewPoly(nil, nil, p.opts)), nil
+		}
+		ext, holes, err := p.parseRingSeq()
+		if err != nil {
+			return nil, err
+		}
+		return geojson.NewPolygon(geometry.NewPoly(ext, holes, p.opts)), nil
+	case "MULTIPOINT":
+		if p.isEmpty() {
+			return geojson.NewMultiPoint(nil), nil
+		}
+		pts, err := p.parseMultiPointSeq()
+		if err != nil {
+			return nil, err
+		}
+		return geojson.NewMultiPoint(pts), nil
+	case "MULTILINESTRING":
+		if p.isEmpty() {
+			return geojson.NewMultiLineString(nil), nil
+		}
+		if _, err := p.expect(tokLP); err != nil {
+			return nil, err
+		}
+		var lines []*geometry.Line
+		for {
+			pts, err := p.parsePointSeq()
+			if err != nil {
+				return nil, err
+			}
+			lines = append(lines, geometry.NewLine(pts, p.opts))
+			if p.cur.kind == tokComma {
+				p.advance()
+				continue
+			}
+			break
+		}
+		if _, err := p.expect(tokRP); err != nil {
+			return nil, err
+		}
+		return geojson.NewMultiLineString(lines), nil
+	case "MULTIPOLYGON":
+		if p.isEmpty() {
+			return geojson.NewMultiPolygon(nil), nil
+		}
+		if _, err := p.expect(tokLP); err != nil {
+			return nil, err
+		}
+		var polys []*geometry.Poly
+		for {
+			ext, holes, err := p.parseRingSeq()
+			if err != nil {
+				return nil, err
+			}
+			polys = append(polys, geometry.NewPoly(ext, holes, p.opts))
+			if p.cur.kind == tokComma {
+				p.advance()
+				continue
+			}
+			break
+		}
+		if _, err := p.expect(tokRP); err != nil {
+			return nil, err
+		}
+		return geojson.NewMultiPolygon(polys), nil
+	case "GEOMETRYCOLLECTION":
+		if p.isEmpty() {
+			return geojson.NewGeometryCollection(nil), nil
+		}
+		if _, err := p.expect(tokLP); err != nil {
+			return nil, err
+		}
+		var geoms []geojson.Object
+		for {
+			g, err := p.parseGeometry()
+			if err != nil {
+				return nil, err
+			}
+			geoms = append(geoms, g)
+			if p.cur.kind == tokComma {
+				p.advance()
+				continue
+			}
+			break
+		}
+		if _, err := p.expect(tokRP); err != nil {
+			return nil, err
+		}
+		return geojson.NewGeometryCollection(geoms), nil
+	default:
+		return nil, errAt(kindPos, "unknown geometry type %q", kind)
+	}
+}
+
+// Parse reads a single WKT geometry, e.g. "POLYGON((0 0, 0 1, 1 1, 0 0))",
+// and returns it as a geojson.Object. Z/M/ZM coordinates are accepted but
+// downcast to 2D. opts is forwarded to the underlying geometry index
+// construction, mirroring geojson.Parse.
+func Parse(s string, opts *geometry.IndexOptions) (geojson.Object, error) {
+	p := &parser{lx: newLexer(s), opts: opts}
+	p.advance()
+	obj, err := p.parseGeometry()
+	if err != nil {
+		return nil, err
+	}
+	if p.cur.kind != tokEOF {
+		return nil, errAt(p.cur.pos, "unexpected trailing token %q", p.cur.text)
+	}
+	return obj, nil
+}
@@ -0,0 +1,112 @@
+package wkt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tidwall/geojson"
+)
+
+func TestParseMultiPointFlatForm(t *testing.T) {
+	obj, err := Parse("MULTIPOINT (1 2, 3 4)", nil)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	mp := obj.(*geojson.MultiPoint)
+	if len(mp.Children()) != 2 {
+		t.Fatalf("expected 2 points, got %d", len(mp.Children()))
+	}
+}
+
+func TestParseMultiPointParenthesizedForm(t *testing.T) {
+	flat, err := Parse("MULTIPOINT (1 2, 3 4)", nil)
+	if err != nil {
+		t.Fatalf("Parse(flat) error: %v", err)
+	}
+	paren, err := Parse("MULTIPOINT ((1 2), (3 4))", nil)
+	if err != nil {
+		t.Fatalf("Parse(parenthesized) error: %v", err)
+	}
+	fp := flat.(*geojson.MultiPoint).Children()
+	pp := paren.(*geojson.MultiPoint).Children()
+	if len(fp) != len(pp) {
+		t.Fatalf("expected both forms to produce %d points, got %d", len(fp), len(pp))
+	}
+	for i := range fp {
+		fc := fp[i].(*geojson.Point).Base()
+		pc := pp[i].(*geojson.Point).Base()
+		if fc != pc {
+			t.Fatalf("expected point %d to match between forms, got %v and %v", i, fc, pc)
+		}
+	}
+}
+
+func TestParseMultiPointMixedForm(t *testing.T) {
+	obj, err := Parse("MULTIPOINT (1 2, (3 4))", nil)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	mp := obj.(*geojson.MultiPoint)
+	if len(mp.Children()) != 2 {
+		t.Fatalf("expected 2 points, got %d", len(mp.Children()))
+	}
+}
+
+func TestParseUnknownGeometryTypeReportsKeywordPosition(t *testing.T) {
+	_, err := Parse("BOGUS (1 2)", nil)
+	if err == nil {
+		t.Fatalf("expected an error for an unknown geometry type")
+	}
+	if !strings.Contains(err.Error(), "at position 0") {
+		t.Fatalf("expected the error to point at the keyword's position, got %q", err.Error())
+	}
+}
+
+func TestParseUnknownGeometryTypeAfterWhitespaceReportsKeywordPosition(t *testing.T) {
+	_, err := Parse("  BOGUS Z (1 2)", nil)
+	if err == nil {
+		t.Fatalf("expected an error for an unknown geometry type")
+	}
+	if !strings.Contains(err.Error(), "at position 2") {
+		t.Fatalf("expected the error to point at the keyword's position, got %q", err.Error())
+	}
+}
+
+func TestParseEmptyGeometries(t *testing.T) {
+	for _, s := range []string{
+		"POINT EMPTY",
+		"LINESTRING EMPTY",
+		"POLYGON EMPTY",
+		"MULTIPOINT EMPTY",
+		"MULTILINESTRING EMPTY",
+		"MULTIPOLYGON EMPTY",
+		"GEOMETRYCOLLECTION EMPTY",
+	} {
+		if _, err := Parse(s, nil); err != nil {
+			t.Fatalf("Parse(%q) error: %v", s, err)
+		}
+	}
+}
+
+func TestParsePointZMDowncastsTo2D(t *testing.T) {
+	obj, err := Parse("POINT ZM (1 2 3 4)", nil)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	p := obj.(*geojson.Point).Base()
+	if p.X != 1 || p.Y != 2 {
+		t.Fatalf("expected (1,2) after downcast, got %v", p)
+	}
+}
+
+func TestParsePolygonWithHole(t *testing.T) {
+	obj, err := Parse(
+		"POLYGON ((0 0, 0 10, 10 10, 10 0, 0 0), (2 2, 2 4, 4 4, 4 2, 2 2))", nil)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	poly := obj.(*geojson.Polygon).Base()
+	if len(poly.Holes) != 1 {
+		t.Fatalf("expected 1 hole, got %d", len(poly.Holes))
+	}
+}
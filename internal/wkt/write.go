@@ -0,0 +1,115 @@
+package wkt
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/geojson"
+	"github.com/tidwall/geojson/geometry"
+)
+
+func appendFloat(b []byte, f float64) []byte {
+	return strconv.AppendFloat(b, f, 'f', -1, 64)
+}
+
+func appendPoint(b []byte, pt geometry.Point) []byte {
+	b = appendFloat(b, pt.X)
+	b = append(b, ' ')
+	b = appendFloat(b, pt.Y)
+	return b
+}
+
+func appendLine(b []byte, line geometry.Ring) []byte {
+	b = append(b, '(')
+	n := line.NumPoints()
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b = append(b, ',', ' ')
+		}
+		b = appendPoint(b, line.PointAt(i))
+	}
+	b = append(b, ')')
+	return b
+}
+
+func appendPoly(b []byte, poly *geometry.Poly) []byte {
+	b = append(b, '(')
+	b = appendLine(b, poly.Exterior)
+	for _, hole := range poly.Holes {
+		b = append(b, ',')
+		b = appendLine(b, hole)
+	}
+	b = append(b, ')')
+	return b
+}
+
+// Write renders a geojson.Object as Well-Known Text. A Feature is written
+// as its geometry; any other type that Parse does not produce (e.g. Circle,
+// Rect) is written as a POINT at the center of its bounding rect.
+func Write(o geojson.Object) string {
+	var b []byte
+	switch g := o.(type) {
+	case *geojson.Point:
+		b = append(b, "POINT ("...)
+		b = appendPoint(b, g.Base())
+		b = append(b, ')')
+	case *geojson.LineString:
+		b = append(b, "LINESTRING "...)
+		b = appendLine(b, g.Base())
+	case *geojson.Polygon:
+		b = append(b, "POLYGON "...)
+		b = appendPoly(b, g.Base())
+	case *geojson.MultiPoint:
+		b = append(b, "MULTIPOINT ("...)
+		for i, child := range g.Children() {
+			if i > 0 {
+				b = append(b, ',', ' ')
+			}
+			b = appendPoint(b, child.(*geojson.Point).Base())
+		}
+		b = append(b, ')')
+	case *geojson.MultiLineString:
+		b = append(b, "MULTILINESTRING ("...)
+		for i, child := range g.Children() {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendLine(b, child.(*geojson.LineString).Base())
+		}
+		b = append(b, ')')
+	case *geojson.MultiPolygon:
+		b = append(b, "MULTIPOLYGON ("...)
+		for i, child := range g.Children() {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendPoly(b, child.(*geojson.Polygon).Base())
+		}
+		b = append(b, ')')
+	case *geojson.GeometryCollection:
+		parts := make([]string, 0, len(g.Children()))
+		for _, child := range g.Children() {
+			parts = append(parts, Write(child))
+		}
+		b = append(b, "GEOMETRYCOLLECTION ("...)
+		b = append(b, strings.Join(parts, ", ")...)
+		b = append(b, ')')
+	case *geojson.Feature:
+		// Features -- the form SET ... OBJECT {"type":"Feature",...} stores
+		// -- carry no geometry of their own; unwrap to the underlying
+		// geometry instead of falling through to the centroid fallback.
+		return Write(g.Base())
+	default:
+		// Fall back to a point at the object's center for any geometry
+		// type Parse does not itself produce (e.g. Circle, Rect).
+		rect := o.Rect()
+		center := geometry.Point{
+			X: (rect.Min.X + rect.Max.X) / 2,
+			Y: (rect.Min.Y + rect.Max.Y) / 2,
+		}
+		b = append(b, "POINT ("...)
+		b = appendPoint(b, center)
+		b = append(b, ')')
+	}
+	return string(b)
+}